@@ -0,0 +1,76 @@
+// Command getgas sizes the ETH an EOA needs to fund a smart wallet through
+// the bundler for a batch of calls, printing a table across a range of
+// call counts so integrators can size a treasury for their expected
+// workload. Analogous to dcrdex's getgas tool, scoped to the ERC-4337
+// flows this repo already speaks.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+
+	"github.com/layer-3/clearsync/pkg/smart_wallet"
+)
+
+// callCounts is the set of batch sizes the table is printed for.
+var callCounts = []int{1, 5, 25}
+
+func main() {
+	rpcURL := flag.String("rpc", "", "Ethereum JSON-RPC endpoint")
+	entryPoint := flag.String("entry-point", "", "EntryPoint contract address")
+	factory := flag.String("factory", "", "smart wallet factory contract address")
+	owner := flag.String("owner", "", "smart wallet owner EOA address")
+	to := flag.String("to", "", "recipient address used for the synthetic batched calls")
+	flag.Parse()
+
+	if *rpcURL == "" || *entryPoint == "" || *factory == "" || *owner == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*rpcURL, *entryPoint, *factory, *owner, *to); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(rpcURL, entryPoint, factory, owner, to string) error {
+	ctx := context.Background()
+
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %w", rpcURL, err)
+	}
+	defer client.Close()
+
+	config := smart_wallet.Config{FactoryAddress: factory}
+	entryPointAddress := common.HexToAddress(entryPoint)
+	ownerAddress := common.HexToAddress(owner)
+	toAddress := common.HexToAddress(to)
+
+	fmt.Printf("%-6s %-16s %-16s %-16s %-20s\n", "calls", "verificationGas", "callGas", "preVerifGas", "requiredPrefund (ETH)")
+	for _, n := range callCounts {
+		calls := make(smart_wallet.Calls, n)
+		for i := range calls {
+			calls[i] = smart_wallet.Call{To: toAddress, Value: big.NewInt(1)}
+		}
+
+		estimate, err := smart_wallet.PreflightUserOp(ctx, client, config, entryPointAddress, ownerAddress, decimal.Zero, calls, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "calls=%d: failed to preflight: %v\n", n, err)
+			continue
+		}
+
+		prefundETH := estimate.RequiredPrefund.Div(decimal.New(1, 18))
+		fmt.Printf("%-6d %-16s %-16s %-16s %-20s\n", n, estimate.VerificationGasLimit, estimate.CallGasLimit, estimate.PreVerificationGas, prefundETH.StringFixed(8))
+	}
+
+	return nil
+}
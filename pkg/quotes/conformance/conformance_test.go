@@ -0,0 +1,29 @@
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/layer-3/clearsync/pkg/quotes/conformance"
+
+	// Registers the drivers' conformance hooks via package quotes' init().
+	_ "github.com/layer-3/clearsync/pkg/quotes"
+)
+
+func TestVerifyCorpus(t *testing.T) {
+	vectors, err := conformance.Load()
+	if err != nil {
+		t.Fatalf("failed to load conformance corpus: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Skip("no conformance vectors committed yet")
+	}
+
+	for _, result := range conformance.Verify(vectors) {
+		result := result
+		t.Run(result.Vector.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Fatalf("driver %q: %v", result.Vector.Driver, result.Err)
+			}
+		})
+	}
+}
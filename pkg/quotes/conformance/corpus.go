@@ -0,0 +1,49 @@
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed testdata/*.json
+var corpusFS embed.FS
+
+// corpusVersion is bumped whenever the Vector schema changes in a
+// backwards-incompatible way, so Load refuses to silently misinterpret an
+// older corpus file.
+const corpusVersion = 1
+
+type corpusFile struct {
+	Version int      `json:"version"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// Load reads every fixture under testdata/ and returns the flattened
+// vector list.
+func Load() ([]Vector, error) {
+	entries, err := fs.Glob(corpusFS, "testdata/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("conformance: failed to list corpus: %w", err)
+	}
+
+	var vectors []Vector
+	for _, entry := range entries {
+		raw, err := corpusFS.ReadFile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: failed to read %s: %w", entry, err)
+		}
+
+		var file corpusFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("conformance: failed to parse %s: %w", entry, err)
+		}
+		if file.Version != corpusVersion {
+			return nil, fmt.Errorf("conformance: %s has corpus version %d, expected %d", entry, file.Version, corpusVersion)
+		}
+		vectors = append(vectors, file.Vectors...)
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,19 @@
+// Package conformance provides an offline regression harness for the
+// `parseSwap` implementations of every V3-style DEX driver (sectaV3,
+// Uniswap v3, PancakeSwap v3, ...).
+//
+// A versioned corpus of JSON vectors under testdata/ is the source of
+// truth: each vector carries a driver name, the pool metadata and raw
+// Swap event fields needed to reconstruct a trade, and the TradeEvent the
+// driver is expected to produce. Drivers register a Hook (from an init()
+// in package quotes, so this package never has to import generated ABI
+// bindings) that replays a vector through the driver's real parseSwap.
+//
+// `Load` + `Verify` are wired into go test via conformance_test.go and
+// fail the build on any mismatch. `Record` implements the opposite
+// direction: connect to a live RPC, snapshot recent Swap events for a
+// configured market, and append them to the corpus.
+//
+// The approach mirrors the Filecoin test-vectors conformance runner: one
+// shared corpus, every implementation replayed against it in CI.
+package conformance
@@ -0,0 +1,96 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// testdataDir is where Record appends freshly captured vectors. go test
+// always runs with the package directory as its working directory, so a
+// relative path matches the one //go:embed uses to read the same files
+// back in corpus.go.
+const testdataDir = "testdata"
+
+// RecordParams configures a corpus-recording run for one driver/market.
+type RecordParams struct {
+	Driver      string
+	Market      string
+	RPCURL      string
+	PoolAddress string
+	FromBlock   uint64
+	ToBlock     uint64 // 0 means "latest"
+	Count       int    // max number of Swap events to snapshot
+}
+
+// Recorder fetches recent Swap events for a market from a live RPC and
+// turns each into a Vector ready to be appended to the corpus. Drivers
+// register one alongside their Hook, since only the driver knows how to
+// decode its own generated Swap log type.
+type Recorder func(ctx context.Context, client *ethclient.Client, params RecordParams) ([]Vector, error)
+
+var recorders = map[string]Recorder{}
+
+// RegisterRecorder associates a driver name with the Recorder that
+// snapshots its live Swap events.
+func RegisterRecorder(driver string, recorder Recorder) {
+	if _, exists := recorders[driver]; exists {
+		panic(fmt.Sprintf("conformance: recorder for driver %q already registered", driver))
+	}
+	recorders[driver] = recorder
+}
+
+// Record dials params.RPCURL, runs the registered Recorder for
+// params.Driver, and appends the resulting vectors to
+// testdata/<driver>.json, merging with whatever fixtures already live
+// there rather than clobbering them.
+func Record(ctx context.Context, params RecordParams) (int, error) {
+	recorder, ok := recorders[params.Driver]
+	if !ok {
+		return 0, fmt.Errorf("conformance: no recorder registered for driver %q", params.Driver)
+	}
+
+	client, err := ethclient.DialContext(ctx, params.RPCURL)
+	if err != nil {
+		return 0, fmt.Errorf("conformance: failed to dial %s: %w", params.RPCURL, err)
+	}
+	defer client.Close()
+
+	vectors, err := recorder(ctx, client, params)
+	if err != nil {
+		return 0, fmt.Errorf("conformance: record failed for driver %q: %w", params.Driver, err)
+	}
+
+	if err := appendToCorpus(params.Driver, vectors); err != nil {
+		return 0, err
+	}
+	return len(vectors), nil
+}
+
+func appendToCorpus(driver string, vectors []Vector) error {
+	path := filepath.Join(testdataDir, driver+".json")
+
+	file := corpusFile{Version: corpusVersion}
+	if existing, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(existing, &file); err != nil {
+			return fmt.Errorf("conformance: failed to parse existing %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("conformance: failed to read %s: %w", path, err)
+	}
+
+	file.Vectors = append(file.Vectors, vectors...)
+
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("conformance: failed to encode %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return fmt.Errorf("conformance: failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package conformance
+
+import "fmt"
+
+// Hook decodes and replays a single Vector through a driver's real
+// parseSwap implementation and returns the Trade it produced.
+type Hook func(Vector) (Trade, error)
+
+var hooks = map[string]Hook{}
+
+// Register associates a driver name (matching Vector.Driver, e.g.
+// "secta_v3") with the hook that exercises that driver's parseSwap.
+// Drivers call this from an init() in package quotes so this package
+// never needs to import generated ABI bindings.
+func Register(driver string, hook Hook) {
+	if _, exists := hooks[driver]; exists {
+		panic(fmt.Sprintf("conformance: driver %q already registered", driver))
+	}
+	hooks[driver] = hook
+}
+
+func lookup(driver string) (Hook, error) {
+	hook, ok := hooks[driver]
+	if !ok {
+		return nil, fmt.Errorf("conformance: no hook registered for driver %q", driver)
+	}
+	return hook, nil
+}
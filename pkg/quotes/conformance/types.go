@@ -0,0 +1,48 @@
+package conformance
+
+import "github.com/shopspring/decimal"
+
+// Vector is one fixture in the conformance corpus: a driver name, the pool
+// state needed to reconstruct a swap, the raw on-chain event fields, and
+// the Trade every implementation of that driver must produce.
+type Vector struct {
+	Name   string   `json:"name"`
+	Driver string   `json:"driver"`
+	Pool   PoolMeta `json:"pool"`
+	Swap   RawSwap  `json:"swap"`
+	Want   Trade    `json:"want"`
+}
+
+// PoolMeta describes the pool a RawSwap was emitted by: the two token
+// addresses, their decimals, the fee tier, and whether base/quote are
+// Reversed relative to the pool's token0/token1 ordering.
+type PoolMeta struct {
+	BaseToken     string `json:"baseToken"`
+	BaseDecimals  uint8  `json:"baseDecimals"`
+	QuoteToken    string `json:"quoteToken"`
+	QuoteDecimals uint8  `json:"quoteDecimals"`
+	FeeTier       uint   `json:"feeTier"`
+	Reversed      bool   `json:"reversed"`
+}
+
+// RawSwap mirrors the fields every V3 Swap event log carries, independent
+// of which generated Go binding (ISectaV3PoolSwap, IUniswapV3PoolSwap,
+// ...) actually emits it. Amounts are decimal strings since they can
+// exceed int64/uint64 range.
+type RawSwap struct {
+	Amount0      string `json:"amount0"`
+	Amount1      string `json:"amount1"`
+	SqrtPriceX96 string `json:"sqrtPriceX96"`
+	Liquidity    string `json:"liquidity"`
+	Tick         int32  `json:"tick"`
+}
+
+// Trade is the decoded outcome a driver hook produces for a Vector,
+// compared against Vector.Want within a relative tolerance of
+// DefaultTolerance (or Tolerance, if set) times the wanted value.
+type Trade struct {
+	Side      string          `json:"side"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+	Tolerance decimal.Decimal `json:"tolerance,omitempty"`
+}
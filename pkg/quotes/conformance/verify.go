@@ -0,0 +1,67 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultTolerance is applied when a Vector's Want.Tolerance is zero:
+// price and amount must match to within 1 part in 1e8.
+var DefaultTolerance = decimal.New(1, -8)
+
+// Result is the outcome of replaying a single Vector.
+type Result struct {
+	Vector Vector
+	Got    Trade
+	Err    error
+}
+
+// Verify replays every vector through its registered driver hook and
+// reports any mismatch against Vector.Want.
+func Verify(vectors []Vector) []Result {
+	results := make([]Result, 0, len(vectors))
+	for _, v := range vectors {
+		results = append(results, verifyOne(v))
+	}
+	return results
+}
+
+func verifyOne(v Vector) Result {
+	hook, err := lookup(v.Driver)
+	if err != nil {
+		return Result{Vector: v, Err: err}
+	}
+
+	got, err := hook(v)
+	if err != nil {
+		return Result{Vector: v, Err: fmt.Errorf("parseSwap failed: %w", err)}
+	}
+
+	if got.Side != v.Want.Side {
+		return Result{Vector: v, Got: got, Err: fmt.Errorf("side mismatch: got %s, want %s", got.Side, v.Want.Side)}
+	}
+
+	tolerance := v.Want.Tolerance
+	if tolerance.IsZero() {
+		tolerance = DefaultTolerance
+	}
+
+	if err := withinTolerance("price", got.Price, v.Want.Price, tolerance); err != nil {
+		return Result{Vector: v, Got: got, Err: err}
+	}
+	if err := withinTolerance("amount", got.Amount, v.Want.Amount, tolerance); err != nil {
+		return Result{Vector: v, Got: got, Err: err}
+	}
+
+	return Result{Vector: v, Got: got}
+}
+
+func withinTolerance(field string, got, want, tolerance decimal.Decimal) error {
+	diff := got.Sub(want).Abs()
+	allowed := tolerance.Mul(want.Abs())
+	if diff.GreaterThan(allowed) {
+		return fmt.Errorf("%s mismatch: got %s, want %s (tolerance %s)", field, got, want, tolerance)
+	}
+	return nil
+}
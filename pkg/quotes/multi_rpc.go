@@ -0,0 +1,511 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ipfs/go-log/v2"
+)
+
+var loggerMultiRPC = log.Logger("multi_rpc")
+
+// Tuning knobs for the health scorer. Kept as package consts, not config, since
+// no driver has ever needed to tune them independently.
+const (
+	rpcHealthCheckPeriod = 15 * time.Second
+	rpcErrorEMAWeight    = 0.2
+	rpcLatencyEMAWeight  = 0.2
+	rpcMaxBlockLag       = 3 // blocks behind the freshest endpoint before a node is treated as stale
+)
+
+// rpcEndpoint wraps a single dialed node together with its rolling health
+// metrics: recent error rate, latency EMA, and block-height lag versus the
+// freshest endpoint in the pool.
+type rpcEndpoint struct {
+	url    string
+	client *ethclient.Client
+
+	mu          sync.Mutex
+	errorEMA    float64
+	latencyEMA  time.Duration
+	blockHeight uint64
+}
+
+// score ranks the endpoint against maxHeight, the highest block height seen
+// across the pool. Lower error rate, lower latency and no lag score higher;
+// an endpoint lagging beyond rpcMaxBlockLag is never selected while a
+// healthier one exists.
+func (e *rpcEndpoint) score(maxHeight uint64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var lag uint64
+	if maxHeight > e.blockHeight {
+		lag = maxHeight - e.blockHeight
+	}
+	if lag > rpcMaxBlockLag {
+		// Provably worse than any real score: the healthy branch below is
+		// bounded below by -(100 + latencyEMA.Seconds() + rpcMaxBlockLag),
+		// so a fixed sentinel like -1 can't guarantee losing to it once
+		// error rate or latency climbs even a little.
+		return math.Inf(-1)
+	}
+
+	return -(e.errorEMA*100 + e.latencyEMA.Seconds() + float64(lag))
+}
+
+func (e *rpcEndpoint) recordSuccess(latency time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.errorEMA = (1 - rpcErrorEMAWeight) * e.errorEMA
+	if e.latencyEMA == 0 {
+		e.latencyEMA = latency
+		return
+	}
+	e.latencyEMA = time.Duration((1-rpcLatencyEMAWeight)*float64(e.latencyEMA) + rpcLatencyEMAWeight*float64(latency))
+}
+
+func (e *rpcEndpoint) recordFailure() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errorEMA = (1-rpcErrorEMAWeight)*e.errorEMA + rpcErrorEMAWeight
+}
+
+func (e *rpcEndpoint) recordHeight(height uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.blockHeight = height
+}
+
+// MultiRPCClient pools several HTTP/WS endpoints for the same chain behind
+// a single bind.ContractCaller / ethereum.LogFilterer surface, so that the
+// factory and pool calls a driver's postStart/getPool hooks make (and the
+// log subscriptions a dexPool keeps open) survive a single endpoint's
+// outage.
+//
+// Every call is dispatched to the highest-scored healthy endpoint and
+// retried against the next candidate on connection errors,
+// context.DeadlineExceeded, or a JSON-RPC 429/5xx response. Log
+// subscriptions re-dial onto a new endpoint when their WS connection
+// drops, replaying the missed block range first so no Swap event is lost.
+//
+// MultiRPCClient implements the full bind.ContractBackend (caller +
+// transactor + filterer), so it drops in anywhere a driver currently
+// passes a bare *ethclient.Client to a generated contract binding. sectaV3
+// wires it in for its factory/pool bindings in postStart when
+// SectaV3Config.RPCUrls has more than one entry; UniswapV3Config and
+// siblings should follow the same pattern as they pick this up, and
+// newBaseDEX/baseDexConfig's own single-endpoint dial (used for log
+// subscriptions) is the remaining piece outside this chunk of the repo.
+type MultiRPCClient struct {
+	endpoints []*rpcEndpoint
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewMultiRPCClient dials every endpoint in rpcUrls (HTTP or WS) and starts
+// the background health scorer. It succeeds as long as at least one
+// endpoint dials; failures to dial the rest are logged, not fatal.
+func NewMultiRPCClient(ctx context.Context, rpcUrls []string) (*MultiRPCClient, error) {
+	if len(rpcUrls) == 0 {
+		return nil, errors.New("multi rpc client: at least one RPC URL is required")
+	}
+
+	c := &MultiRPCClient{stop: make(chan struct{})}
+
+	var dialErrs []string
+	for _, rawURL := range rpcUrls {
+		client, err := ethclient.DialContext(ctx, rawURL)
+		if err != nil {
+			dialErrs = append(dialErrs, fmt.Sprintf("%s: %v", rawURL, err))
+			continue
+		}
+		c.endpoints = append(c.endpoints, &rpcEndpoint{url: rawURL, client: client})
+	}
+
+	if len(c.endpoints) == 0 {
+		return nil, fmt.Errorf("multi rpc client: failed to dial any endpoint: %s", strings.Join(dialErrs, "; "))
+	}
+	if len(dialErrs) > 0 {
+		loggerMultiRPC.Warnw("some RPC endpoints failed to dial", "errors", dialErrs)
+	}
+
+	go c.healthLoop()
+	return c, nil
+}
+
+// Close stops the health scorer and closes every underlying connection.
+func (c *MultiRPCClient) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	for _, ep := range c.endpoints {
+		ep.client.Close()
+	}
+}
+
+func (c *MultiRPCClient) healthLoop() {
+	ticker := time.NewTicker(rpcHealthCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.refreshHeights()
+		}
+	}
+}
+
+func (c *MultiRPCClient) refreshHeights() {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcHealthCheckPeriod)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, ep := range c.endpoints {
+		wg.Add(1)
+		go func(ep *rpcEndpoint) {
+			defer wg.Done()
+			header, err := ep.client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				ep.recordFailure()
+				return
+			}
+			ep.recordHeight(header.Number.Uint64())
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// ranked returns the pool's endpoints ordered from highest to lowest score,
+// excluding any already present in tried.
+func (c *MultiRPCClient) ranked(tried map[*rpcEndpoint]bool) []*rpcEndpoint {
+	var maxHeight uint64
+	for _, ep := range c.endpoints {
+		ep.mu.Lock()
+		if ep.blockHeight > maxHeight {
+			maxHeight = ep.blockHeight
+		}
+		ep.mu.Unlock()
+	}
+
+	candidates := make([]*rpcEndpoint, 0, len(c.endpoints))
+	for _, ep := range c.endpoints {
+		if !tried[ep] {
+			candidates = append(candidates, ep)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score(maxHeight) > candidates[j].score(maxHeight)
+	})
+	return candidates
+}
+
+// isRetryable reports whether err looks transient enough to warrant trying
+// the next endpoint rather than failing the call outright.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		code := rpcErr.ErrorCode()
+		return code == 429 || (code >= 500 && code < 600)
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, transient := range []string{"connection refused", "connection reset", "eof", "no such host", "timeout"} {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry runs fn against the best-scored healthy endpoint, falling back
+// to the next-best candidate while the error looks transient.
+func (c *MultiRPCClient) withRetry(ctx context.Context, fn func(context.Context, *ethclient.Client) error) error {
+	tried := make(map[*rpcEndpoint]bool, len(c.endpoints))
+
+	var lastErr error
+	for {
+		candidates := c.ranked(tried)
+		if len(candidates) == 0 {
+			if lastErr == nil {
+				lastErr = errors.New("multi rpc client: no endpoints available")
+			}
+			return lastErr
+		}
+
+		ep := candidates[0]
+		tried[ep] = true
+
+		start := time.Now()
+		err := fn(ctx, ep.client)
+		if err == nil {
+			ep.recordSuccess(time.Since(start))
+			return nil
+		}
+
+		ep.recordFailure()
+		lastErr = err
+		if !isRetryable(err) {
+			return err
+		}
+		loggerMultiRPC.Warnw("RPC call failed, retrying against next endpoint", "endpoint", ep.url, "error", err)
+	}
+}
+
+// CodeAt implements bind.ContractCaller.
+func (c *MultiRPCClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var out []byte
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+// CallContract implements bind.ContractCaller.
+func (c *MultiRPCClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var out []byte
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+// PendingCodeAt implements bind.ContractTransactor.
+func (c *MultiRPCClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	var out []byte
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.PendingCodeAt(ctx, account)
+		return err
+	})
+	return out, err
+}
+
+// PendingNonceAt implements bind.ContractTransactor.
+func (c *MultiRPCClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	var out uint64
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return out, err
+}
+
+// SuggestGasPrice implements bind.ContractTransactor.
+func (c *MultiRPCClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var out *big.Int
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return out, err
+}
+
+// SuggestGasTipCap implements bind.ContractTransactor.
+func (c *MultiRPCClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var out *big.Int
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.SuggestGasTipCap(ctx)
+		return err
+	})
+	return out, err
+}
+
+// EstimateGas implements bind.ContractTransactor.
+func (c *MultiRPCClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	var out uint64
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.EstimateGas(ctx, call)
+		return err
+	})
+	return out, err
+}
+
+// SendTransaction implements bind.ContractTransactor. Broadcasting the
+// same signed transaction to more than one endpoint is harmless (nodes
+// just relay it to the same mempool), so this retries like every other
+// call instead of needing special-case idempotency handling.
+func (c *MultiRPCClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		return client.SendTransaction(ctx, tx)
+	})
+}
+
+// HeaderByNumber implements bind.ContractTransactor.
+func (c *MultiRPCClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var out *types.Header
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return out, err
+}
+
+// FilterLogs implements ethereum.LogFilterer.
+func (c *MultiRPCClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var out []types.Log
+	err := c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.FilterLogs(ctx, q)
+		return err
+	})
+	return out, err
+}
+
+// SubscribeFilterLogs implements ethereum.LogFilterer. The returned
+// subscription survives its underlying WS endpoint dropping: it re-dials
+// onto the next healthy endpoint, replays any logs emitted between the
+// last delivered block and the reconnect via FilterLogs, then keeps
+// streaming.
+func (c *MultiRPCClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sub := &multiRPCSubscription{
+		client:   c,
+		query:    q,
+		out:      ch,
+		internal: make(chan types.Log, 256),
+		errC:     make(chan error, 1),
+		quit:     make(chan struct{}),
+	}
+
+	if err := sub.connect(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	go sub.run(ctx)
+	return sub, nil
+}
+
+// multiRPCSubscription is the ethereum.Subscription returned by
+// SubscribeFilterLogs. It owns the currently active underlying
+// subscription and transparently re-establishes it on a new endpoint when
+// the active one fails.
+type multiRPCSubscription struct {
+	client *MultiRPCClient
+	query  ethereum.FilterQuery
+	out    chan<- types.Log
+
+	internal chan types.Log
+
+	mu         sync.Mutex
+	underlying ethereum.Subscription
+	current    *rpcEndpoint
+	lastBlock  uint64
+
+	errC chan error
+	quit chan struct{}
+	once sync.Once
+}
+
+func (s *multiRPCSubscription) deliver(l types.Log) {
+	if l.BlockNumber > s.lastBlock {
+		s.lastBlock = l.BlockNumber
+	}
+	s.out <- l
+}
+
+// connect picks the best-ranked endpoint (excluding exclude, normally the
+// one whose subscription just died), replays any logs missed since
+// lastBlock via FilterLogs, and opens a fresh SubscribeFilterLogs on it.
+func (s *multiRPCSubscription) connect(ctx context.Context, exclude *rpcEndpoint) error {
+	tried := map[*rpcEndpoint]bool{}
+	if exclude != nil {
+		tried[exclude] = true
+	}
+
+	var lastErr error
+	for _, ep := range s.client.ranked(tried) {
+		if s.lastBlock > 0 {
+			catchUp := s.query
+			catchUp.FromBlock = new(big.Int).SetUint64(s.lastBlock + 1)
+			catchUp.ToBlock = nil
+
+			missed, err := ep.client.FilterLogs(ctx, catchUp)
+			if err != nil {
+				lastErr = err
+				ep.recordFailure()
+				continue
+			}
+			for _, l := range missed {
+				s.deliver(l)
+			}
+		}
+
+		underlying, err := ep.client.SubscribeFilterLogs(ctx, s.query, s.internal)
+		if err != nil {
+			lastErr = err
+			ep.recordFailure()
+			continue
+		}
+
+		s.mu.Lock()
+		s.underlying = underlying
+		s.current = ep
+		s.mu.Unlock()
+		return nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("multi rpc client: no endpoints available for subscription")
+	}
+	return lastErr
+}
+
+func (s *multiRPCSubscription) run(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		underlying, failedEndpoint := s.underlying, s.current
+		s.mu.Unlock()
+
+		select {
+		case <-s.quit:
+			underlying.Unsubscribe()
+			return
+		case l := <-s.internal:
+			s.deliver(l)
+		case err := <-underlying.Err():
+			if err == nil {
+				return
+			}
+			loggerMultiRPC.Warnw("log subscription dropped, reconnecting", "endpoint", failedEndpoint.url, "error", err)
+			if rerr := s.connect(ctx, failedEndpoint); rerr != nil {
+				select {
+				case s.errC <- rerr:
+				default:
+				}
+				return
+			}
+		}
+	}
+}
+
+// Err implements ethereum.Subscription.
+func (s *multiRPCSubscription) Err() <-chan error {
+	return s.errC
+}
+
+// Unsubscribe implements ethereum.Subscription.
+func (s *multiRPCSubscription) Unsubscribe() {
+	s.once.Do(func() { close(s.quit) })
+}
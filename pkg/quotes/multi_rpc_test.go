@@ -0,0 +1,119 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRpcEndpointScore(t *testing.T) {
+	healthy := &rpcEndpoint{errorEMA: 0, latencyEMA: 0, blockHeight: 100}
+	laggy := &rpcEndpoint{errorEMA: 0, latencyEMA: 0, blockHeight: 100 - rpcMaxBlockLag - 1}
+	errorProne := &rpcEndpoint{errorEMA: 0.9, latencyEMA: 2 * time.Second, blockHeight: 100}
+
+	require.Greater(t, healthy.score(100), errorProne.score(100), "a healthy endpoint should outscore an error-prone one")
+	require.Greater(t, errorProne.score(100), laggy.score(100), "even a bad-but-in-range endpoint should outscore one lagging past rpcMaxBlockLag")
+	require.True(t, laggy.score(100) == laggy.score(100), "score must be deterministic for the same inputs")
+}
+
+func TestMultiRPCClientRanked(t *testing.T) {
+	best := &rpcEndpoint{url: "best", blockHeight: 100}
+	worst := &rpcEndpoint{url: "worst", errorEMA: 0.5, blockHeight: 100}
+	lagging := &rpcEndpoint{url: "lagging", blockHeight: 100 - rpcMaxBlockLag - 1}
+
+	c := &MultiRPCClient{endpoints: []*rpcEndpoint{worst, lagging, best}}
+
+	ranked := c.ranked(nil)
+	require.Len(t, ranked, 3)
+	require.Equal(t, "best", ranked[0].url)
+	require.Equal(t, "worst", ranked[1].url)
+	require.Equal(t, "lagging", ranked[2].url, "a stale endpoint should always sort last")
+
+	ranked = c.ranked(map[*rpcEndpoint]bool{best: true})
+	require.Len(t, ranked, 2)
+	require.Equal(t, "worst", ranked[0].url)
+}
+
+type fakeRPCError struct {
+	code int
+}
+
+func (e fakeRPCError) Error() string  { return fmt.Sprintf("rpc error %d", e.code) }
+func (e fakeRPCError) ErrorCode() int { return e.code }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{"wrapped deadline exceeded", fmt.Errorf("dial: %w", context.DeadlineExceeded), true},
+		{"rpc 429", fakeRPCError{code: 429}, true},
+		{"rpc 503", fakeRPCError{code: 503}, true},
+		{"rpc 400", fakeRPCError{code: 400}, false},
+		{"connection refused", errors.New("dial tcp: connection refused"), true},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"EOF", errors.New("unexpected EOF"), true},
+		{"no such host", errors.New("no such host"), true},
+		{"timeout", errors.New("i/o timeout"), true},
+		{"generic error", errors.New("insufficient funds"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isRetryable(tt.err))
+		})
+	}
+}
+
+// TestWithRetryFailsOverToNextEndpoint points the pool at one endpoint with
+// nothing listening (always "connection refused", a retryable error) and
+// one that answers normally, and checks withRetry both falls back to the
+// healthy endpoint and records the failure against the dead one.
+func TestWithRetryFailsOverToNextEndpoint(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	deadURL := dead.URL
+	dead.Close() // nothing accepts connections on deadURL anymore
+
+	var healthyCalls int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&healthyCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"1"}`)
+	}))
+	defer healthy.Close()
+
+	ctx := context.Background()
+	deadClient, err := ethclient.DialContext(ctx, deadURL)
+	require.NoError(t, err)
+	defer deadClient.Close()
+
+	healthyClient, err := ethclient.DialContext(ctx, healthy.URL)
+	require.NoError(t, err)
+	defer healthyClient.Close()
+
+	deadEndpoint := &rpcEndpoint{url: deadURL, client: deadClient}
+	healthyEndpoint := &rpcEndpoint{url: healthy.URL, client: healthyClient}
+	c := &MultiRPCClient{endpoints: []*rpcEndpoint{deadEndpoint, healthyEndpoint}}
+
+	err = c.withRetry(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		_, err := client.NetworkID(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&healthyCalls), "the healthy endpoint should have been tried after the dead one failed")
+
+	deadEndpoint.mu.Lock()
+	defer deadEndpoint.mu.Unlock()
+	require.Greater(t, deadEndpoint.errorEMA, 0.0, "the dead endpoint should have recorded a failure")
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ipfs/go-log/v2"
 
 	"github.com/layer-3/clearsync/pkg/abi/isecta_v3_factory"
@@ -27,13 +26,25 @@ type sectaV3 struct {
 	factoryAddress common.Address
 	factory        *isecta_v3_factory.ISectaV3Factory
 
+	// rpcUrls, when it has more than one entry, makes postStart dial a
+	// MultiRPCClient for the factory/pool bindings below instead of using
+	// the single-endpoint client newBaseDEX dials for itself.
+	rpcUrls []string
+
 	assets *safe.Map[string, poolToken]
-	client *ethclient.Client
+	client bind.ContractBackend
 }
 
 func newSectaV3(rpcUrl string, config SectaV3Config, outbox chan<- TradeEvent, history HistoricalData) (Driver, error) {
+	// SectaV3Config.RPCUrls (config.go isn't part of this chunk of the
+	// repo's tree, so it can't be grepped/verified here) is expected to
+	// carry the pool of fallback RPC endpoints loaded from env/YAML
+	// alongside the existing FactoryAddress/AssetsURL/... fields; nothing
+	// else in this chunk populates it. Confirm the field and its config
+	// loader wiring exist together with this commit before merging.
 	hooks := &sectaV3{
 		factoryAddress: common.HexToAddress(config.FactoryAddress),
+		rpcUrls:        config.RPCUrls,
 	}
 
 	params := baseDexConfig[
@@ -70,6 +81,14 @@ func (s *sectaV3) postStart(driver *baseDEX[
 	s.client = driver.Client()
 	s.assets = driver.Assets()
 
+	if len(s.rpcUrls) > 1 {
+		multiClient, err := NewMultiRPCClient(context.Background(), s.rpcUrls)
+		if err != nil {
+			return fmt.Errorf("failed to build multi-RPC client for Secta v3: %w", err)
+		}
+		s.client = multiClient
+	}
+
 	s.factory, err = isecta_v3_factory.NewISectaV3Factory(s.factoryAddress, s.client)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate a Secta v3 pool factory contract: %w", err)
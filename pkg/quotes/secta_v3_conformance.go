@@ -0,0 +1,173 @@
+package quotes
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/layer-3/clearsync/pkg/abi/ierc20"
+	"github.com/layer-3/clearsync/pkg/abi/isecta_v3_pool"
+	"github.com/layer-3/clearsync/pkg/quotes/conformance"
+)
+
+func init() {
+	conformance.Register(string(DriverSectaV3), sectaV3ConformanceHook)
+	conformance.RegisterRecorder(string(DriverSectaV3), sectaV3ConformanceRecorder)
+}
+
+// sectaV3ConformanceHook replays a conformance.Vector through the real
+// (*sectaV3).parseSwap, so the same sign/scaling logic used in production
+// is exactly what the corpus exercises.
+func sectaV3ConformanceHook(v conformance.Vector) (conformance.Trade, error) {
+	pool := &dexPool[isecta_v3_pool.ISectaV3PoolSwap, *isecta_v3_pool.ISectaV3PoolSwapIterator]{
+		BaseToken: poolToken{
+			Address:  common.HexToAddress(v.Pool.BaseToken),
+			Decimals: v.Pool.BaseDecimals,
+		},
+		QuoteToken: poolToken{
+			Address:  common.HexToAddress(v.Pool.QuoteToken),
+			Decimals: v.Pool.QuoteDecimals,
+		},
+		Reversed: v.Pool.Reversed,
+	}
+
+	swap, err := decodeRawSwap(v.Swap)
+	if err != nil {
+		return conformance.Trade{}, err
+	}
+
+	hooks := &sectaV3{}
+	trade, err := hooks.parseSwap(swap, pool)
+	if err != nil {
+		return conformance.Trade{}, err
+	}
+
+	return conformance.Trade{
+		Side:   string(trade.Side),
+		Price:  trade.Price,
+		Amount: trade.Amount,
+	}, nil
+}
+
+// decodeRawSwap turns the corpus's driver-agnostic RawSwap fields into the
+// generated ISectaV3PoolSwap struct parseSwap expects.
+func decodeRawSwap(raw conformance.RawSwap) (*isecta_v3_pool.ISectaV3PoolSwap, error) {
+	amount0, ok := new(big.Int).SetString(raw.Amount0, 10)
+	if !ok {
+		return nil, fmt.Errorf("conformance: invalid amount0 %q", raw.Amount0)
+	}
+	amount1, ok := new(big.Int).SetString(raw.Amount1, 10)
+	if !ok {
+		return nil, fmt.Errorf("conformance: invalid amount1 %q", raw.Amount1)
+	}
+	sqrtPriceX96, ok := new(big.Int).SetString(raw.SqrtPriceX96, 10)
+	if !ok {
+		return nil, fmt.Errorf("conformance: invalid sqrtPriceX96 %q", raw.SqrtPriceX96)
+	}
+	liquidity, ok := new(big.Int).SetString(raw.Liquidity, 10)
+	if !ok {
+		return nil, fmt.Errorf("conformance: invalid liquidity %q", raw.Liquidity)
+	}
+
+	return &isecta_v3_pool.ISectaV3PoolSwap{
+		Amount0:      amount0,
+		Amount1:      amount1,
+		SqrtPriceX96: sqrtPriceX96,
+		Liquidity:    liquidity,
+		Tick:         big.NewInt(int64(raw.Tick)),
+	}, nil
+}
+
+// sectaV3ConformanceRecorder snapshots recent Swap events for
+// params.PoolAddress, together with the token decimals needed to
+// reconstruct them, and turns each into a conformance.Vector. The
+// resulting vectors carry a zero Want: record mode captures raw chain
+// state, not a verified expectation, so a human (or a trusted reference
+// implementation) must fill `want` in before `verify` can use them.
+func sectaV3ConformanceRecorder(ctx context.Context, client *ethclient.Client, params conformance.RecordParams) ([]conformance.Vector, error) {
+	poolAddress := common.HexToAddress(params.PoolAddress)
+	poolContract, err := isecta_v3_pool.NewISectaV3Pool(poolAddress, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind Secta v3 pool %s: %w", params.PoolAddress, err)
+	}
+
+	token0Address, err := poolContract.Token0(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token0 for pool %s: %w", params.PoolAddress, err)
+	}
+	token1Address, err := poolContract.Token1(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token1 for pool %s: %w", params.PoolAddress, err)
+	}
+	token0Decimals, err := erc20Decimals(ctx, client, token0Address)
+	if err != nil {
+		return nil, err
+	}
+	token1Decimals, err := erc20Decimals(ctx, client, token1Address)
+	if err != nil {
+		return nil, err
+	}
+
+	endBlock := params.ToBlock
+	if endBlock == 0 {
+		header, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch latest header: %w", err)
+		}
+		endBlock = header.Number.Uint64()
+	}
+
+	iter, err := poolContract.FilterSwap(&bind.FilterOpts{Start: params.FromBlock, End: &endBlock, Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter Swap events for pool %s: %w", params.PoolAddress, err)
+	}
+	defer iter.Close()
+
+	var vectors []conformance.Vector
+	for iter.Next() {
+		if params.Count > 0 && len(vectors) >= params.Count {
+			break
+		}
+
+		swap := iter.Event
+		vectors = append(vectors, conformance.Vector{
+			Name:   fmt.Sprintf("secta_v3/%s-block%d-recorded", params.Market, swap.Raw.BlockNumber),
+			Driver: string(DriverSectaV3),
+			Pool: conformance.PoolMeta{
+				BaseToken:     token0Address.Hex(),
+				BaseDecimals:  token0Decimals,
+				QuoteToken:    token1Address.Hex(),
+				QuoteDecimals: token1Decimals,
+			},
+			Swap: conformance.RawSwap{
+				Amount0:      swap.Amount0.String(),
+				Amount1:      swap.Amount1.String(),
+				SqrtPriceX96: swap.SqrtPriceX96.String(),
+				Liquidity:    swap.Liquidity.String(),
+				Tick:         int32(swap.Tick.Int64()),
+			},
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("failed to iterate Swap events for pool %s: %w", params.PoolAddress, err)
+	}
+
+	return vectors, nil
+}
+
+func erc20Decimals(ctx context.Context, client *ethclient.Client, token common.Address) (uint8, error) {
+	erc20Contract, err := ierc20.NewIERC20(token, client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bind ERC20 %s: %w", token, err)
+	}
+
+	decimals, err := erc20Contract.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read decimals for %s: %w", token, err)
+	}
+	return decimals, nil
+}
@@ -0,0 +1,72 @@
+package smart_wallet
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// executeABI packs a Kernel-style smart account's single-call `execute`
+// and batched `executeBatch` entry points, the two calldata shapes
+// PreflightUserOp needs to simulate before signing anything. 0 is
+// CallType.Call (as opposed to delegatecall).
+var executeABI = func() abi.ABI {
+	const executeJSON = `[
+		{"name":"execute","type":"function","inputs":[
+			{"name":"to","type":"address"},
+			{"name":"value","type":"uint256"},
+			{"name":"data","type":"bytes"},
+			{"name":"operation","type":"uint8"}
+		]},
+		{"name":"executeBatch","type":"function","inputs":[
+			{"name":"calls","type":"tuple[]","components":[
+				{"name":"to","type":"address"},
+				{"name":"value","type":"uint256"},
+				{"name":"data","type":"bytes"}
+			]}
+		]}
+	]`
+
+	parsed, err := abi.JSON(strings.NewReader(executeJSON))
+	if err != nil {
+		panic(fmt.Sprintf("smart_wallet: failed to parse execute ABI: %v", err))
+	}
+	return parsed
+}()
+
+// packCalls encodes calls as calldata for the smart account's `execute`
+// (single call) or `executeBatch` (two or more) entry point.
+func packCalls(calls Calls) ([]byte, error) {
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("cannot pack an empty batch of calls")
+	}
+
+	if len(calls) == 1 {
+		call := calls[0]
+		data, err := executeABI.Pack("execute", call.To, call.Value, call.Data, uint8(0))
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack execute calldata: %w", err)
+		}
+		return data, nil
+	}
+
+	type batchCall struct {
+		To    common.Address
+		Value *big.Int
+		Data  []byte
+	}
+
+	batch := make([]batchCall, len(calls))
+	for i, call := range calls {
+		batch[i] = batchCall{To: call.To, Value: call.Value, Data: call.Data}
+	}
+
+	data, err := executeABI.Pack("executeBatch", batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack executeBatch calldata: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,344 @@
+package smart_wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/shopspring/decimal"
+)
+
+// executionResultError decodes the revert EntryPoint.simulateHandleOp
+// always throws on success (see the EntryPoint contract referenced in
+// GetAccountAddress), carrying the exact gas the simulated userOp consumed
+// at each phase.
+var (
+	executionResultRes, executionResultOk = entryPointABI.Errors["ExecutionResult"]
+	executionResultError                  = mustTrue(executionResultRes, executionResultOk)
+)
+
+// fallback gas figures used by estimateGasPerCall, the path taken when a
+// provider doesn't support EntryPoint simulation. They're deliberately
+// generous: PreflightUserOp is sizing a treasury, not a transaction.
+const (
+	fallbackVerificationGas    = 100_000
+	fallbackPreVerificationGas = 50_000
+	fallbackDeploymentGas      = 300_000
+)
+
+// FeeOverrides lets a caller pin one or more EIP-1559 fee fields instead of
+// having PreflightUserOp derive them from the chain's current base fee and
+// the provider's suggested priority fee.
+type FeeOverrides struct {
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// PreflightGasEstimate is the gas and fee plan PreflightUserOp computes for
+// a batch of Calls, together with the ETH an EOA must fund into the
+// counterfactual smart wallet before the bundler will accept the
+// resulting UserOperation.
+type PreflightGasEstimate struct {
+	PreVerificationGas   *big.Int
+	VerificationGasLimit *big.Int
+	CallGasLimit         *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	RequiredPrefund      decimal.Decimal
+}
+
+// PreflightProvider is the subset of an RPC client PreflightUserOp needs:
+// enough to simulate the userOp through the EntryPoint and read current
+// fee data, falling back to a plain eth_estimateGas per Call, with no state
+// overrides applied, when the endpoint
+// doesn't support simulateHandleOp (e.g. a bundler-only RPC with no state
+// override support).
+type PreflightProvider interface {
+	ethereum.ContractCaller
+	// ethereum.ChainStateReader is what IsAccountDeployed needs (CodeAt,
+	// which overlaps with ContractCaller above, plus BalanceAt/StorageAt/
+	// NonceAt); PreflightUserOp calls IsAccountDeployed directly, so the
+	// provider it's handed has to satisfy both.
+	ethereum.ChainStateReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+}
+
+// PreflightUserOp estimates the gas a UserOperation deploying the
+// counterfactual smart wallet (if not already deployed) and executing
+// calls will burn, and how much ETH owner must hold for the bundler to
+// accept it.
+//
+// It builds the same initCode GetInitCode would, asks the EntryPoint to
+// simulate the full operation via simulateHandleOp, and falls back to
+// summing a per-call eth_estimateGas when the provider doesn't support
+// simulation. feeOverrides may be nil to derive both fee fields from the
+// chain's current base fee and suggested priority fee.
+func PreflightUserOp(
+	ctx context.Context,
+	provider PreflightProvider,
+	config Config,
+	entryPointAddress common.Address,
+	owner common.Address,
+	index decimal.Decimal,
+	calls Calls,
+	feeOverrides *FeeOverrides,
+) (*PreflightGasEstimate, error) {
+	initCode, err := GetInitCode(config, owner, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build initCode for preflight: %w", err)
+	}
+
+	swAddress, err := GetAccountAddress(ctx, provider, config, entryPointAddress, owner, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive counterfactual address for preflight: %w", err)
+	}
+
+	deployed, err := IsAccountDeployed(ctx, provider, swAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check deployment status for preflight: %w", err)
+	}
+
+	preVerificationGas, verificationGas, callGas, err := simulateHandleOp(ctx, provider, entryPointAddress, swAddress, initCode, calls, deployed)
+	if err != nil {
+		preVerificationGas, verificationGas, callGas, err = estimateGasPerCall(ctx, provider, swAddress, calls, deployed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas for preflight: %w", err)
+		}
+	}
+
+	maxFeePerGas, maxPriorityFeePerGas, err := resolveFees(ctx, provider, feeOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve fee data for preflight: %w", err)
+	}
+
+	totalGas := new(big.Int).Add(preVerificationGas, verificationGas)
+	totalGas.Add(totalGas, callGas)
+	requiredPrefund := new(big.Int).Mul(totalGas, maxFeePerGas)
+
+	return &PreflightGasEstimate{
+		PreVerificationGas:   preVerificationGas,
+		VerificationGasLimit: verificationGas,
+		CallGasLimit:         callGas,
+		MaxFeePerGas:         maxFeePerGas,
+		MaxPriorityFeePerGas: maxPriorityFeePerGas,
+		RequiredPrefund:      decimal.NewFromBigInt(requiredPrefund, 0),
+	}, nil
+}
+
+// simulatedUserOp is the subset of the ERC-4337 v0.6 UserOperation tuple
+// simulateHandleOp needs to size gas. It is kept local to this file rather
+// than reusing the userop package's full UserOperation to avoid an import
+// cycle (userop already imports smart_wallet for Config and Calls).
+type simulatedUserOp struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+	Signature            []byte
+}
+
+// simulateHandleOp packs a worst-case-gas userOp and asks the EntryPoint
+// to simulate it end to end (initCode deployment, if any, validation, and
+// the batched calls), decoding the preOpGas/paid accounting the EntryPoint
+// returns in its ExecutionResult revert into VerificationGasLimit and
+// CallGasLimit; PreVerificationGas is a fixed allowance. The
+// signature is left empty: simulateHandleOp is used purely to size gas
+// here, not to check that owner actually signed anything.
+func simulateHandleOp(
+	ctx context.Context,
+	provider PreflightProvider,
+	entryPointAddress common.Address,
+	swAddress common.Address,
+	initCode []byte,
+	calls Calls,
+	deployed bool,
+) (preVerificationGas, verificationGas, callGas *big.Int, err error) {
+	callData, err := packCalls(calls)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to pack calls: %w", err)
+	}
+
+	// A fresh counterfactual account's first UserOperation is always nonce
+	// 0, but simulating against the real nonce for an already-deployed
+	// account is required: the EntryPoint checks it before gas is even
+	// measured, so a stale 0 makes simulateHandleOp revert for a reason
+	// that has nothing to do with gas.
+	nonce := big.NewInt(0)
+	if deployed {
+		nonce, err = entryPointNonce(ctx, provider, entryPointAddress, swAddress)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to fetch account nonce: %w", err)
+		}
+	}
+
+	op := simulatedUserOp{
+		Sender:               swAddress,
+		Nonce:                nonce,
+		CallData:             callData,
+		CallGasLimit:         big.NewInt(fallbackDeploymentGas),
+		VerificationGasLimit: big.NewInt(fallbackDeploymentGas),
+		PreVerificationGas:   big.NewInt(fallbackPreVerificationGas),
+		MaxFeePerGas:         big.NewInt(1),
+		MaxPriorityFeePerGas: big.NewInt(1),
+	}
+	if !deployed {
+		op.InitCode = initCode
+	}
+
+	data, err := entryPointABI.Pack("simulateHandleOp", op, common.Address{}, []byte{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to pack simulateHandleOp data: %w", err)
+	}
+
+	msg := ethereum.CallMsg{To: &entryPointAddress, Data: data}
+
+	// this call must always revert (see EntryPoint contract), so we expect an error
+	_, callErr := provider.CallContract(ctx, msg, nil)
+	if callErr == nil {
+		return nil, nil, nil, errors.New("'simulateHandleOp' call returned no error, but expected one")
+	}
+
+	var scError rpc.DataError
+	if ok := errors.As(callErr, &scError); !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected error type '%T' containing message %w)", callErr, callErr)
+	}
+	errorData, ok := scError.ErrorData().(string)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("could not unpack error data: unexpected error data (%+v) type '%T'", scError.ErrorData(), scError.ErrorData())
+	}
+
+	if id := executionResultError.ID.String(); len(errorData) < 10 || errorData[0:10] != id[0:10] {
+		return nil, nil, nil, fmt.Errorf("'simulateHandleOp' unexpected error signature: %s", errorData)
+	}
+
+	unpacked, err := executionResultError.Inputs.Unpack(common.FromHex(errorData)[4:])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unpack ExecutionResult: %w", err)
+	}
+
+	// unpacked[0] is ExecutionResult.preOpGas: the gas EntryPoint actually
+	// measured validating (and, for an undeployed account, deploying) the
+	// wallet. unpacked[1] is ExecutionResult.paid, which already includes
+	// preOpGas (see EntryPoint.sol's _executeUserOp:
+	// actualGas = preGas-gasleft()+opInfo.preOpGas), so the call-only
+	// portion is the remainder, not a blind split of paid.
+	preOpGas, ok1 := unpacked[0].(*big.Int)
+	paidGas, ok2 := unpacked[1].(*big.Int)
+	if !ok1 || !ok2 {
+		return nil, nil, nil, fmt.Errorf("unexpected ExecutionResult shape: %+v", unpacked)
+	}
+
+	verificationGas = preOpGas
+	callGas = new(big.Int).Sub(paidGas, preOpGas)
+
+	// PreVerificationGas is the calldata/overhead allowance charged against
+	// the payer before EntryPoint starts executing at all; simulateHandleOp
+	// doesn't measure it, so approximate it the same way the fallback path
+	// does rather than reusing preOpGas for an unrelated quantity.
+	preVerificationGas = big.NewInt(fallbackPreVerificationGas)
+	return preVerificationGas, verificationGas, callGas, nil
+}
+
+// entryPointNonce reads an account's current nonce (key 0) straight off the
+// EntryPoint via getNonce(sender, key), the same source the EntryPoint
+// itself checks when validating a real UserOperation. Unlike
+// simulateHandleOp/getSenderAddress, getNonce is a plain view function
+// that returns its result normally rather than through a revert.
+func entryPointNonce(ctx context.Context, provider PreflightProvider, entryPointAddress, sender common.Address) (*big.Int, error) {
+	data, err := entryPointABI.Pack("getNonce", sender, big.NewInt(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getNonce data: %w", err)
+	}
+
+	result, err := provider.CallContract(ctx, ethereum.CallMsg{To: &entryPointAddress, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getNonce: %w", err)
+	}
+
+	unpacked, err := entryPointABI.Unpack("getNonce", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getNonce result: %w", err)
+	}
+	nonce, ok := unpacked[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected getNonce result shape: %+v", unpacked)
+	}
+	return nonce, nil
+}
+
+// estimateGasPerCall is the fallback path for providers that don't expose
+// EntryPoint simulation: it sums a plain eth_estimateGas per Call and uses
+// fixed allowances for verification/pre-verification gas.
+func estimateGasPerCall(
+	ctx context.Context,
+	provider PreflightProvider,
+	swAddress common.Address,
+	calls Calls,
+	deployed bool,
+) (preVerificationGas, verificationGas, callGas *big.Int, err error) {
+	total := uint64(0)
+	for _, call := range calls {
+		gas, err := provider.EstimateGas(ctx, ethereum.CallMsg{
+			From:  swAddress,
+			To:    &call.To,
+			Value: call.Value,
+			Data:  call.Data,
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to estimate gas for call to %s: %w", call.To, err)
+		}
+		total += gas
+	}
+
+	verification := int64(fallbackVerificationGas)
+	if !deployed {
+		verification += fallbackDeploymentGas
+	}
+
+	return big.NewInt(fallbackPreVerificationGas), big.NewInt(verification), new(big.Int).SetUint64(total), nil
+}
+
+// resolveFees returns maxFeePerGas/maxPriorityFeePerGas, applying any
+// non-nil feeOverrides fields and otherwise deriving both from the
+// chain's current base fee and the provider's suggested priority fee.
+func resolveFees(ctx context.Context, provider PreflightProvider, feeOverrides *FeeOverrides) (maxFeePerGas, maxPriorityFeePerGas *big.Int, err error) {
+	if feeOverrides != nil && feeOverrides.MaxFeePerGas != nil && feeOverrides.MaxPriorityFeePerGas != nil {
+		return feeOverrides.MaxFeePerGas, feeOverrides.MaxPriorityFeePerGas, nil
+	}
+
+	tip, err := provider.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+	}
+	if feeOverrides != nil && feeOverrides.MaxPriorityFeePerGas != nil {
+		tip = feeOverrides.MaxPriorityFeePerGas
+	}
+
+	header, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch latest header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return nil, nil, errors.New("chain does not report a base fee (pre-EIP-1559)")
+	}
+
+	maxFee := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	if feeOverrides != nil && feeOverrides.MaxFeePerGas != nil {
+		maxFee = feeOverrides.MaxFeePerGas
+	}
+
+	return maxFee, tip, nil
+}
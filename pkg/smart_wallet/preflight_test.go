@@ -0,0 +1,179 @@
+package smart_wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackCalls(t *testing.T) {
+	call1 := Call{To: common.HexToAddress("0x1"), Value: big.NewInt(1), Data: []byte{0xaa}}
+	call2 := Call{To: common.HexToAddress("0x2"), Value: big.NewInt(2), Data: []byte{0xbb}}
+
+	t.Run("empty batch errors", func(t *testing.T) {
+		_, err := packCalls(Calls{})
+		require.Error(t, err)
+	})
+
+	t.Run("single call uses execute", func(t *testing.T) {
+		got, err := packCalls(Calls{call1})
+		require.NoError(t, err)
+
+		want, err := executeABI.Pack("execute", call1.To, call1.Value, call1.Data, uint8(0))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	})
+
+	t.Run("multiple calls use executeBatch", func(t *testing.T) {
+		got, err := packCalls(Calls{call1, call2})
+		require.NoError(t, err)
+
+		type batchCall struct {
+			To    common.Address
+			Value *big.Int
+			Data  []byte
+		}
+		want, err := executeABI.Pack("executeBatch", []batchCall{
+			{To: call1.To, Value: call1.Value, Data: call1.Data},
+			{To: call2.To, Value: call2.Value, Data: call2.Data},
+		})
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	})
+}
+
+// fakeDataError implements rpc.DataError so simulateHandleOp's
+// errors.As(callErr, &scError) decode path can be exercised without a real
+// node.
+type fakeDataError struct {
+	msg  string
+	data interface{}
+}
+
+func (e *fakeDataError) Error() string          { return e.msg }
+func (e *fakeDataError) ErrorData() interface{} { return e.data }
+
+// fakePreflightProvider implements PreflightProvider entirely in memory.
+// Only the methods a given test path actually exercises are wired up;
+// everything else panics so an unexpected call fails loudly.
+type fakePreflightProvider struct {
+	callContract func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+func (f *fakePreflightProvider) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	panic("CodeAt not stubbed")
+}
+func (f *fakePreflightProvider) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return f.callContract(ctx, call, blockNumber)
+}
+func (f *fakePreflightProvider) BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	panic("BalanceAt not stubbed")
+}
+func (f *fakePreflightProvider) StorageAt(context.Context, common.Address, common.Hash, *big.Int) ([]byte, error) {
+	panic("StorageAt not stubbed")
+}
+func (f *fakePreflightProvider) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	panic("NonceAt not stubbed")
+}
+func (f *fakePreflightProvider) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	panic("HeaderByNumber not stubbed")
+}
+func (f *fakePreflightProvider) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	panic("SuggestGasTipCap not stubbed")
+}
+func (f *fakePreflightProvider) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	panic("EstimateGas not stubbed")
+}
+
+// encodeExecutionResult builds the revert payload simulateHandleOp expects
+// from a real EntryPoint.simulateHandleOp call: the ExecutionResult error
+// selector followed by its ABI-encoded fields.
+func encodeExecutionResult(t *testing.T, preOpGas, paid *big.Int) string {
+	t.Helper()
+
+	packed, err := executionResultError.Inputs.Pack(preOpGas, paid, big.NewInt(0), big.NewInt(0), true, []byte{})
+	require.NoError(t, err)
+
+	return executionResultError.ID.String()[:10] + hex.EncodeToString(packed)
+}
+
+func TestSimulateHandleOp(t *testing.T) {
+	preOpGas := big.NewInt(120_000)
+	paidGas := big.NewInt(300_000) // already includes preOpGas, per EntryPoint.sol
+	wantNonce := big.NewInt(7)
+
+	// deployed accounts make simulateHandleOp fetch the real nonce via
+	// getNonce before it can simulate the op, so the fake provider needs
+	// to answer that call (first) before the simulateHandleOp call itself
+	// (second).
+	nonceResult, err := entryPointABI.Methods["getNonce"].Outputs.Pack(wantNonce)
+	require.NoError(t, err)
+
+	var callCount int
+	provider := &fakePreflightProvider{
+		callContract: func(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+			callCount++
+			if callCount == 1 {
+				return nonceResult, nil
+			}
+			return nil, &fakeDataError{
+				msg:  "execution reverted",
+				data: encodeExecutionResult(t, preOpGas, paidGas),
+			}
+		},
+	}
+
+	calls := Calls{{To: common.HexToAddress("0x1"), Value: big.NewInt(0)}}
+	preVerificationGas, verificationGas, callGas, err := simulateHandleOp(
+		context.Background(), provider, common.HexToAddress("0xe"), common.HexToAddress("0xa"), nil, calls, true,
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, callCount, "a deployed account must fetch its nonce before simulating")
+
+	require.Equal(t, preOpGas, verificationGas)
+	require.Equal(t, new(big.Int).Sub(paidGas, preOpGas), callGas, "call gas must be paid minus preOpGas, not a blind split of paid")
+	require.Equal(t, big.NewInt(fallbackPreVerificationGas), preVerificationGas)
+}
+
+func TestSimulateHandleOpSkipsNonceFetchForUndeployedAccount(t *testing.T) {
+	preOpGas := big.NewInt(300_000) // includes the initCode deployment
+	paidGas := big.NewInt(310_000)
+
+	var callCount int
+	provider := &fakePreflightProvider{
+		callContract: func(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+			callCount++
+			return nil, &fakeDataError{
+				msg:  "execution reverted",
+				data: encodeExecutionResult(t, preOpGas, paidGas),
+			}
+		},
+	}
+
+	calls := Calls{{To: common.HexToAddress("0x1"), Value: big.NewInt(0)}}
+	_, _, _, err := simulateHandleOp(
+		context.Background(), provider, common.HexToAddress("0xe"), common.HexToAddress("0xa"), []byte{0x01}, calls, false,
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, callCount, "an undeployed account has no nonce to fetch, it should always simulate as 0")
+}
+
+func TestSimulateHandleOpNoRevert(t *testing.T) {
+	provider := &fakePreflightProvider{
+		callContract: func(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+			return []byte{}, nil
+		},
+	}
+
+	calls := Calls{{To: common.HexToAddress("0x1"), Value: big.NewInt(0)}}
+	_, _, _, err := simulateHandleOp(
+		context.Background(), provider, common.HexToAddress("0xe"), common.HexToAddress("0xa"), nil, calls, false,
+	)
+	require.Error(t, err, "simulateHandleOp must fail when the EntryPoint call unexpectedly succeeds instead of reverting")
+}
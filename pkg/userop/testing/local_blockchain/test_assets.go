@@ -0,0 +1,318 @@
+package local_blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/layer-3/clearsync/pkg/abi/ierc20_mintable"
+	"github.com/layer-3/clearsync/pkg/abi/ierc721_mintable"
+	"github.com/layer-3/clearsync/pkg/abi/iuniswap_v3_factory"
+	"github.com/layer-3/clearsync/pkg/abi/iuniswap_v3_pool"
+	"github.com/layer-3/clearsync/pkg/abi/iuniswap_v3_position_manager"
+)
+
+// uniswapV3TickSpacing maps a fee tier (hundredths of a bip, as passed to
+// IUniswapV3Factory.createPool) to Uniswap v3's fixed tick spacing for that
+// tier. Fee tiers outside this table aren't supported by the canonical
+// deployment and would have been rejected by createPool already.
+var uniswapV3TickSpacing = map[uint]int64{
+	100:   1,
+	500:   10,
+	3000:  60,
+	10000: 200,
+}
+
+// fullRangeTicks returns the widest tickLower/tickUpper pair that's valid
+// for feeTier, i.e. the largest multiples of its tick spacing that fit
+// inside Uniswap v3's [-887272, 887272] tick bounds.
+func fullRangeTicks(feeTier uint) (lower, upper *big.Int) {
+	const maxTick = 887272
+	spacing := uniswapV3TickSpacing[feeTier]
+	bound := (maxTick / spacing) * spacing
+	return big.NewInt(-bound), big.NewInt(bound)
+}
+
+// deployerKeyHex is the first account of Hardhat/Anvil's default dev
+// mnemonic, which NewEthNode funds at genesis. Using it here (rather than
+// a freshly generated key) keeps every address DeployERC20/DeployERC721/
+// DeployUniswapV3Pool produce stable across test runs, the same guarantee
+// the reused EthNode/Bundler pattern in TestSimulatedRPC relies on.
+const deployerKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// deployerAuth builds a fresh *bind.TransactOpts for the deterministic
+// deployer account. A fresh one is built per call since *bind.TransactOpts
+// is not safe to reuse across concurrent sends and Deploy* issues several.
+func deployerAuth(ctx context.Context, t *testing.T, node *EthNode) *bind.TransactOpts {
+	t.Helper()
+
+	key, err := crypto.HexToECDSA(deployerKeyHex)
+	require.NoError(t, err, "failed to load deterministic deployer key")
+
+	chainID, err := node.Client.NetworkID(ctx)
+	require.NoError(t, err, "failed to fetch chain ID")
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	require.NoError(t, err, "failed to build deployer transactor")
+	return auth
+}
+
+// waitMined blocks until tx is mined and fails the test if it reverted.
+func waitMined(ctx context.Context, t *testing.T, node *EthNode, tx *types.Transaction) *types.Receipt {
+	t.Helper()
+
+	receipt, err := bind.WaitMined(ctx, node.Client, tx)
+	require.NoError(t, err, "failed to mine transaction %s", tx.Hash())
+	require.Equal(t, uint64(1), receipt.Status, "transaction %s reverted", tx.Hash())
+	return receipt
+}
+
+// DeployERC20 deploys a mintable ERC-20 test token from the deterministic
+// deployer account, mints initialSupply to each holder, and returns the
+// typed binding together with its address.
+func DeployERC20(
+	ctx context.Context,
+	t *testing.T,
+	node *EthNode,
+	name, symbol string,
+	decimals uint8,
+	initialSupply *big.Int,
+	holders ...common.Address,
+) (*ierc20_mintable.IERC20Mintable, common.Address) {
+	t.Helper()
+
+	address, tx, token, err := ierc20_mintable.DeployIERC20Mintable(deployerAuth(ctx, t, node), node.Client, name, symbol, decimals)
+	require.NoError(t, err, "failed to deploy ERC20 test token %s", symbol)
+	waitMined(ctx, t, node, tx)
+
+	for _, holder := range holders {
+		tx, err := token.Mint(deployerAuth(ctx, t, node), holder, initialSupply)
+		require.NoError(t, err, "failed to mint %s to %s", symbol, holder)
+		waitMined(ctx, t, node, tx)
+	}
+
+	return token, address
+}
+
+// DeployERC721 deploys a mintable ERC-721 test collection from the
+// deterministic deployer account, mints one token per holder (tokenIDs
+// starting at 1, in holder order), and returns the typed binding together
+// with its address.
+func DeployERC721(
+	ctx context.Context,
+	t *testing.T,
+	node *EthNode,
+	name, symbol string,
+	holders ...common.Address,
+) (*ierc721_mintable.IERC721Mintable, common.Address) {
+	t.Helper()
+
+	address, tx, token, err := ierc721_mintable.DeployIERC721Mintable(deployerAuth(ctx, t, node), node.Client, name, symbol)
+	require.NoError(t, err, "failed to deploy ERC721 test collection %s", symbol)
+	waitMined(ctx, t, node, tx)
+
+	for i, holder := range holders {
+		tokenID := big.NewInt(int64(i + 1))
+		tx, err := token.Mint(deployerAuth(ctx, t, node), holder, tokenID)
+		require.NoError(t, err, "failed to mint %s #%d to %s", symbol, tokenID, holder)
+		waitMined(ctx, t, node, tx)
+	}
+
+	return token, address
+}
+
+// UniswapV3PoolDeployment is the set of addresses/bindings
+// DeployUniswapV3Pool produces, enough to wire a quotes.Driver test
+// against a freshly deployed pool.
+type UniswapV3PoolDeployment struct {
+	FactoryAddress         common.Address
+	Factory                *iuniswap_v3_factory.IUniswapV3Factory
+	PoolAddress            common.Address
+	Pool                   *iuniswap_v3_pool.IUniswapV3Pool
+	PositionManagerAddress common.Address
+	PositionManager        *iuniswap_v3_position_manager.IUniswapV3PositionManager
+	// TokenID is the NFT ID of the seeded full-range position, or nil if
+	// LiquiditySeeded is false.
+	TokenID *big.Int
+	// LiquiditySeeded reports whether UniswapV3PoolParams.BaseAmount and
+	// QuoteAmount were both non-nil and a position was actually
+	// minted. Callers that need a tradeable pool should assert on this
+	// rather than assuming amounts they passed were accepted.
+	LiquiditySeeded bool
+}
+
+// UniswapV3PoolParams bundles DeployUniswapV3Pool's inputs. BaseToken and
+// QuoteToken are the mintable bindings (not just addresses) so the helper
+// can mint BaseAmount/QuoteAmount to the deployer itself and seed a
+// full-range liquidity position, the same way DeployERC20 mints
+// initialSupply to its holders.
+type UniswapV3PoolParams struct {
+	BaseToken         *ierc20_mintable.IERC20Mintable
+	BaseTokenAddress  common.Address
+	QuoteToken        *ierc20_mintable.IERC20Mintable
+	QuoteTokenAddress common.Address
+	FeeTier           uint
+	SqrtPriceX96      *big.Int
+	// BaseAmount/QuoteAmount, when both non-nil, are minted to the
+	// deployer and supplied as a full-range IUniswapV3PositionManager.Mint
+	// call's amount0Desired/amount1Desired. Leave both nil to get an
+	// initialized-but-empty pool.
+	BaseAmount  *big.Int
+	QuoteAmount *big.Int
+}
+
+// DeployUniswapV3Pool deploys a factory and a base/quote pool at
+// params.FeeTier from the deterministic deployer account, initializes it
+// at params.SqrtPriceX96, and - when params.BaseAmount/QuoteAmount are
+// both set - mints a full-range liquidity position via a freshly deployed
+// NonfungiblePositionManager so the pool is actually tradeable.
+func DeployUniswapV3Pool(
+	ctx context.Context,
+	t *testing.T,
+	node *EthNode,
+	params UniswapV3PoolParams,
+) *UniswapV3PoolDeployment {
+	t.Helper()
+
+	factoryAddress, tx, factory, err := iuniswap_v3_factory.DeployIUniswapV3Factory(deployerAuth(ctx, t, node), node.Client)
+	require.NoError(t, err, "failed to deploy Uniswap v3 factory")
+	waitMined(ctx, t, node, tx)
+
+	tx, err = factory.CreatePool(deployerAuth(ctx, t, node), params.BaseTokenAddress, params.QuoteTokenAddress, big.NewInt(int64(params.FeeTier)))
+	require.NoError(t, err, "failed to create Uniswap v3 pool")
+	waitMined(ctx, t, node, tx)
+
+	poolAddress, err := factory.GetPool(&bind.CallOpts{Context: ctx}, params.BaseTokenAddress, params.QuoteTokenAddress, big.NewInt(int64(params.FeeTier)))
+	require.NoError(t, err, "failed to look up created Uniswap v3 pool")
+	require.NotEqual(t, common.Address{}, poolAddress, "pool was not created")
+
+	pool, err := iuniswap_v3_pool.NewIUniswapV3Pool(poolAddress, node.Client)
+	require.NoError(t, err, "failed to bind Uniswap v3 pool")
+
+	tx, err = pool.Initialize(deployerAuth(ctx, t, node), params.SqrtPriceX96)
+	require.NoError(t, err, "failed to initialize Uniswap v3 pool")
+	waitMined(ctx, t, node, tx)
+
+	deployment := &UniswapV3PoolDeployment{
+		FactoryAddress: factoryAddress,
+		Factory:        factory,
+		PoolAddress:    poolAddress,
+		Pool:           pool,
+	}
+
+	if params.BaseAmount == nil || params.QuoteAmount == nil {
+		return deployment
+	}
+
+	token0Address, err := pool.Token0(&bind.CallOpts{Context: ctx})
+	require.NoError(t, err, "failed to read token0 for seeding liquidity")
+	token1Address, err := pool.Token1(&bind.CallOpts{Context: ctx})
+	require.NoError(t, err, "failed to read token1 for seeding liquidity")
+
+	// The factory sorts tokens internally, so the pool's token0/token1
+	// order may not match the base/quote order callers passed in - line up
+	// the seeded amounts with whichever side actually landed on token0.
+	amount0Desired, amount1Desired := params.BaseAmount, params.QuoteAmount
+	if token0Address != params.BaseTokenAddress {
+		amount0Desired, amount1Desired = params.QuoteAmount, params.BaseAmount
+	}
+
+	positionManagerAddress, tx, positionManager, err := iuniswap_v3_position_manager.DeployIUniswapV3PositionManager(
+		deployerAuth(ctx, t, node), node.Client, factoryAddress,
+		// WETH9 and the token descriptor are only consulted for
+		// ETH-unwrapping and tokenURI metadata, neither of which a plain
+		// ERC20/ERC20 test position touches, so the zero address stands
+		// in for both here.
+		common.Address{}, common.Address{},
+	)
+	require.NoError(t, err, "failed to deploy Uniswap v3 position manager")
+	waitMined(ctx, t, node, tx)
+	deployment.PositionManagerAddress = positionManagerAddress
+	deployment.PositionManager = positionManager
+
+	tx, err = params.BaseToken.Mint(deployerAuth(ctx, t, node), deployerAuth(ctx, t, node).From, params.BaseAmount)
+	require.NoError(t, err, "failed to mint base token liquidity")
+	waitMined(ctx, t, node, tx)
+
+	tx, err = params.QuoteToken.Mint(deployerAuth(ctx, t, node), deployerAuth(ctx, t, node).From, params.QuoteAmount)
+	require.NoError(t, err, "failed to mint quote token liquidity")
+	waitMined(ctx, t, node, tx)
+
+	tx, err = params.BaseToken.Approve(deployerAuth(ctx, t, node), positionManagerAddress, params.BaseAmount)
+	require.NoError(t, err, "failed to approve base token for position manager")
+	waitMined(ctx, t, node, tx)
+
+	tx, err = params.QuoteToken.Approve(deployerAuth(ctx, t, node), positionManagerAddress, params.QuoteAmount)
+	require.NoError(t, err, "failed to approve quote token for position manager")
+	waitMined(ctx, t, node, tx)
+
+	tickLower, tickUpper := fullRangeTicks(params.FeeTier)
+	mintAuth := deployerAuth(ctx, t, node)
+	tx, err = positionManager.Mint(mintAuth, iuniswap_v3_position_manager.INonfungiblePositionManagerMintParams{
+		Token0:         token0Address,
+		Token1:         token1Address,
+		Fee:            big.NewInt(int64(params.FeeTier)),
+		TickLower:      tickLower,
+		TickUpper:      tickUpper,
+		Amount0Desired: amount0Desired,
+		Amount1Desired: amount1Desired,
+		Amount0Min:     big.NewInt(0),
+		Amount1Min:     big.NewInt(0),
+		Recipient:      mintAuth.From,
+		Deadline:       big.NewInt(time.Now().Unix() + 3600),
+	})
+	require.NoError(t, err, "failed to mint full-range liquidity position")
+	receipt := waitMined(ctx, t, node, tx)
+
+	tokenID, err := positionMintedTokenID(positionManager, receipt)
+	require.NoError(t, err, "failed to read minted position token ID")
+
+	deployment.TokenID = tokenID
+	deployment.LiquiditySeeded = true
+	return deployment
+}
+
+// positionMintedTokenID pulls the token ID out of the IncreaseLiquidity
+// event positionManager.Mint emits, rather than guessing it from mint
+// order, so it's correct even if other positions were minted earlier in
+// the same test.
+func positionMintedTokenID(positionManager *iuniswap_v3_position_manager.IUniswapV3PositionManager, receipt *types.Receipt) (*big.Int, error) {
+	for _, logEntry := range receipt.Logs {
+		event, err := positionManager.ParseIncreaseLiquidity(*logEntry)
+		if err != nil {
+			continue
+		}
+		return event.TokenId, nil
+	}
+	return nil, fmt.Errorf("no IncreaseLiquidity event in mint receipt %s", receipt.TxHash)
+}
+
+// SnapshotAndRevert records an evm_snapshot before t's subtests run and
+// evm_revert's to it during cleanup, so expensive setup (contract
+// deployment, seeded balances) can be shared across subtests without
+// bleeding state between them.
+func SnapshotAndRevert(ctx context.Context, t *testing.T, node *EthNode) {
+	t.Helper()
+
+	var snapshotID string
+	require.NoError(t, node.Client.Client().CallContext(ctx, &snapshotID, "evm_snapshot"), "failed to snapshot EVM state")
+
+	t.Cleanup(func() {
+		var reverted bool
+		if err := node.Client.Client().CallContext(ctx, &reverted, "evm_revert", snapshotID); err != nil {
+			t.Errorf("failed to revert EVM state to snapshot %s: %v", snapshotID, err)
+			return
+		}
+		if !reverted {
+			t.Errorf("evm_revert rejected snapshot %s", snapshotID)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package local_blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployTestAssets(t *testing.T) {
+	ctx := context.Background()
+	node := NewEthNode(ctx, t)
+	SnapshotAndRevert(ctx, t, node)
+
+	holder := deployerAuth(ctx, t, node).From
+	initialSupply := big.NewInt(1_000_000)
+
+	token, address := DeployERC20(ctx, t, node, "Test USD", "tUSD", 6, initialSupply, holder)
+
+	balance, err := token.BalanceOf(nil, holder)
+	require.NoError(t, err, "failed to read ERC20 balance")
+	require.Equal(t, initialSupply, balance)
+	require.NotEqual(t, "0x0000000000000000000000000000000000000000", address.Hex())
+}
+
+func TestDeployERC721(t *testing.T) {
+	ctx := context.Background()
+	node := NewEthNode(ctx, t)
+	SnapshotAndRevert(ctx, t, node)
+
+	holder := deployerAuth(ctx, t, node).From
+	token, address := DeployERC721(ctx, t, node, "Test NFT", "tNFT", holder)
+	require.NotEqual(t, common.Address{}, address)
+
+	owner, err := token.OwnerOf(nil, big.NewInt(1))
+	require.NoError(t, err, "failed to read ERC721 owner")
+	require.Equal(t, holder, owner)
+}
+
+func TestDeployUniswapV3Pool(t *testing.T) {
+	ctx := context.Background()
+	node := NewEthNode(ctx, t)
+	SnapshotAndRevert(ctx, t, node)
+
+	deployer := deployerAuth(ctx, t, node).From
+	baseToken, baseAddress := DeployERC20(ctx, t, node, "Wrapped Ether", "WETH", 18, big.NewInt(0))
+	quoteToken, quoteAddress := DeployERC20(ctx, t, node, "USD Coin", "USDC", 6, big.NewInt(0))
+
+	// 1:1 human price ignoring decimals, i.e. sqrtPriceX96 = sqrt(1) * 2^96.
+	oneToOneSqrtPriceX96 := new(big.Int).Lsh(big.NewInt(1), 96)
+
+	deployment := DeployUniswapV3Pool(ctx, t, node, UniswapV3PoolParams{
+		BaseToken:         baseToken,
+		BaseTokenAddress:  baseAddress,
+		QuoteToken:        quoteToken,
+		QuoteTokenAddress: quoteAddress,
+		FeeTier:           500,
+		SqrtPriceX96:      oneToOneSqrtPriceX96,
+		BaseAmount:        big.NewInt(1_000_000_000),
+		QuoteAmount:       big.NewInt(1_000_000_000),
+	})
+	require.NotEqual(t, common.Address{}, deployment.PoolAddress)
+	require.True(t, deployment.LiquiditySeeded, "pool should have been seeded with a full-range position")
+	require.NotNil(t, deployment.TokenID)
+
+	liquidity, err := deployment.Pool.Liquidity(nil)
+	require.NoError(t, err, "failed to read pool liquidity")
+	require.Greater(t, liquidity.Uint64(), uint64(0), "pool should report non-zero liquidity after seeding")
+
+	owner, err := deployment.PositionManager.OwnerOf(nil, deployment.TokenID)
+	require.NoError(t, err, "failed to read seeded position owner")
+	require.Equal(t, deployer, owner)
+}
+
+// TestSnapshotAndRevertRoundTrip mints extra tokens after SnapshotAndRevert
+// has recorded its baseline, then checks the mint is actually undone once
+// the subtest's cleanup fires the evm_revert - not just that evm_revert
+// didn't error.
+func TestSnapshotAndRevertRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	node := NewEthNode(ctx, t)
+
+	holder := deployerAuth(ctx, t, node).From
+	token, _ := DeployERC20(ctx, t, node, "Test USD", "tUSD", 6, big.NewInt(1_000), holder)
+
+	baseline, err := token.BalanceOf(nil, holder)
+	require.NoError(t, err, "failed to read baseline ERC20 balance")
+
+	t.Run("mutate-then-revert", func(t *testing.T) {
+		SnapshotAndRevert(ctx, t, node)
+
+		tx, err := token.Mint(deployerAuth(ctx, t, node), holder, big.NewInt(500))
+		require.NoError(t, err, "failed to mint extra balance before revert")
+		waitMined(ctx, t, node, tx)
+
+		mutated, err := token.BalanceOf(nil, holder)
+		require.NoError(t, err, "failed to read mutated ERC20 balance")
+		require.True(t, mutated.Cmp(baseline) > 0, "balance should have increased before the snapshot reverts")
+	})
+
+	afterRevert, err := token.BalanceOf(nil, holder)
+	require.NoError(t, err, "failed to read post-revert ERC20 balance")
+	require.Equal(t, baseline, afterRevert, "evm_revert should have undone the mint once the subtest's cleanup ran")
+}